@@ -0,0 +1,64 @@
+package service
+
+import "sync"
+
+// BargeInSignaler fans a single barge-in notification out to every
+// subscriber. A Transcriber publishes to it when SpeechStarted fires
+// while the assistant is talking; the TTS/LLM pipeline subscribes to
+// learn when to cancel the in-flight response.
+type BargeInSignaler struct {
+	mu                sync.Mutex
+	subs              map[chan struct{}]struct{}
+	assistantSpeaking bool
+}
+
+// NewBargeInSignaler returns an empty signaler. One is normally shared
+// across every Transcriber in a room.
+func NewBargeInSignaler() *BargeInSignaler {
+	return &BargeInSignaler{subs: make(map[chan struct{}]struct{})}
+}
+
+// Subscribe returns a channel that receives a value when Publish is
+// called, and an unsubscribe function to release it. The channel holds
+// at most one pending notification; a Publish made while the previous
+// one is still unread is not delivered again.
+func (b *BargeInSignaler) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}
+}
+
+// Publish notifies every current subscriber that a barge-in happened.
+func (b *BargeInSignaler) Publish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// SetAssistantSpeaking records whether the assistant is currently
+// talking. Transcriber only publishes a barge-in when this is true.
+func (b *BargeInSignaler) SetAssistantSpeaking(speaking bool) {
+	b.mu.Lock()
+	b.assistantSpeaking = speaking
+	b.mu.Unlock()
+}
+
+func (b *BargeInSignaler) isAssistantSpeaking() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.assistantSpeaking
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+)
+
+// MP3Encoder is the minimal surface Mixer needs from an MP3 encoding
+// library (a lame or minimp3 binding): feed it interleaved 16-bit PCM
+// and it writes encoded MP3 bytes to the underlying io.Writer.
+type MP3Encoder interface {
+	io.WriteCloser
+}
+
+// MP3EncoderFactory builds a fresh MP3Encoder writing to w for the given
+// sample rate and channel count.
+type MP3EncoderFactory func(w io.Writer, sampleRate, channels int) (MP3Encoder, error)
+
+// PCMSource is anything a Mixer can pull interleaved 16-bit PCM samples
+// from, typically a TranscriberPCMSource decoding a Transcriber's raw
+// Opus frames (see WithPCMOutput).
+type PCMSource interface {
+	// ReadPCM reads up to len(buf) samples, returning how many were
+	// read. io.EOF indicates the source is done for good.
+	ReadPCM(buf []int16) (int, error)
+}
+
+// Mixer sums multiple PCMSources into a single channel and MP3-encodes
+// the result for archival, so a multi-party call produces one
+// listenable recording instead of N separate per-track files.
+type Mixer struct {
+	sampleRate int
+	channels   int
+	newEncoder MP3EncoderFactory
+
+	lock    sync.Mutex
+	sources map[string]PCMSource
+}
+
+// NewMixer returns a Mixer at the given sample rate/channel count,
+// encoding with whatever MP3Encoder newEncoder builds.
+func NewMixer(sampleRate, channels int, newEncoder MP3EncoderFactory) *Mixer {
+	return &Mixer{
+		sampleRate: sampleRate,
+		channels:   channels,
+		newEncoder: newEncoder,
+		sources:    make(map[string]PCMSource),
+	}
+}
+
+// AddSource registers a PCMSource under id (typically a participant
+// identity) to be included in future mixes.
+func (m *Mixer) AddSource(id string, source PCMSource) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.sources[id] = source
+}
+
+// RemoveSource unregisters a previously added source.
+func (m *Mixer) RemoveSource(id string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.sources, id)
+}
+
+// WriteTo mixes every registered source and MP3-encodes the result into
+// w until ctx is canceled or every source has returned io.EOF. It reads
+// one 20ms frame per source per tick, so a non-blocking PCMSource that
+// returns a partial (or empty) read doesn't spin the loop.
+func (m *Mixer) WriteTo(ctx context.Context, w io.Writer) error {
+	enc, err := m.newEncoder(w, m.sampleRate, m.channels)
+	if err != nil {
+		return err
+	}
+	defer enc.Close()
+
+	const frameDuration = 20 * time.Millisecond
+	frameSamples := m.sampleRate / 50 * m.channels // 20ms frames
+	buf := make([]int16, frameSamples)
+	mixed := make([]int32, frameSamples)
+	out := make([]byte, frameSamples*2)
+
+	ticker := time.NewTicker(frameDuration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		sources := m.snapshotSources()
+		if len(sources) == 0 {
+			return nil
+		}
+
+		for i := range mixed {
+			mixed[i] = 0
+		}
+
+		active := 0
+		for _, src := range sources {
+			n, err := src.ReadPCM(buf)
+			if n == 0 && err != nil {
+				continue
+			}
+			active++
+			for i := 0; i < n; i++ {
+				mixed[i] += int32(buf[i])
+			}
+		}
+
+		if active == 0 {
+			return nil
+		}
+
+		for i, s := range mixed {
+			binary.LittleEndian.PutUint16(out[i*2:], uint16(clampSample(s)))
+		}
+
+		if _, err := enc.Write(out); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *Mixer) snapshotSources() []PCMSource {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	sources := make([]PCMSource, 0, len(m.sources))
+	for _, s := range m.sources {
+		sources = append(sources, s)
+	}
+	return sources
+}
+
+func clampSample(s int32) int16 {
+	if s > 32767 {
+		return 32767
+	}
+	if s < -32768 {
+		return -32768
+	}
+	return int16(s)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+func TestClampSample(t *testing.T) {
+	cases := []struct {
+		in   int32
+		want int16
+	}{
+		{0, 0},
+		{32767, 32767},
+		{32768, 32767},
+		{-32768, -32768},
+		{-32769, -32768},
+	}
+	for _, c := range cases {
+		if got := clampSample(c.in); got != c.want {
+			t.Errorf("clampSample(%d) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+// fakePCMSource returns the given samples once, then io.EOF.
+type fakePCMSource struct {
+	samples []int16
+	read    bool
+}
+
+func (s *fakePCMSource) ReadPCM(buf []int16) (int, error) {
+	if s.read {
+		return 0, io.EOF
+	}
+	s.read = true
+	n := copy(buf, s.samples)
+	return n, nil
+}
+
+// nopMP3Encoder just records every Write's bytes, standing in for a real
+// MP3Encoder so WriteTo can be tested without a lame/minimp3 binding.
+type nopMP3Encoder struct {
+	w      io.Writer
+	closed bool
+}
+
+func (e *nopMP3Encoder) Write(p []byte) (int, error) { return e.w.Write(p) }
+func (e *nopMP3Encoder) Close() error                { e.closed = true; return nil }
+
+func TestMixerWriteToSumsAndClamps(t *testing.T) {
+	const sampleRate = 50 // 1 frame == 1 sample per source, keeps the test small
+	m := NewMixer(sampleRate, 1, func(w io.Writer, sampleRate, channels int) (MP3Encoder, error) {
+		return &nopMP3Encoder{w: w}, nil
+	})
+
+	m.AddSource("a", &fakePCMSource{samples: []int16{20000}})
+	m.AddSource("b", &fakePCMSource{samples: []int16{20000}})
+
+	var out bytes.Buffer
+	if err := m.WriteTo(context.Background(), &out); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	if out.Len() != 2 {
+		t.Fatalf("out.Len() = %d, want 2 (one clamped int16 sample)", out.Len())
+	}
+
+	got := int16(uint16(out.Bytes()[0]) | uint16(out.Bytes()[1])<<8)
+	if got != 32767 {
+		t.Fatalf("mixed sample = %d, want 32767 (20000+20000 clamped)", got)
+	}
+}
+
+func TestMixerWriteToReturnsOnNoSources(t *testing.T) {
+	m := NewMixer(50, 1, func(w io.Writer, sampleRate, channels int) (MP3Encoder, error) {
+		return &nopMP3Encoder{w: w}, nil
+	})
+
+	if err := m.WriteTo(context.Background(), io.Discard); err != nil {
+		t.Fatalf("WriteTo with no sources: %v", err)
+	}
+}
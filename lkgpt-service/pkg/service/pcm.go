@@ -0,0 +1,88 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/pion/rtp"
+)
+
+// maxOpusFrameSamples is large enough to hold the biggest Opus frame a
+// decoder can produce (120ms at 48kHz, stereo) so OpusDecoder.Decode
+// never needs to be called twice per RTP packet.
+const maxOpusFrameSamples = 48000 / 1000 * 120 * 2
+
+// OpusDecoder decodes a single Opus payload into interleaved 16-bit PCM
+// samples. It matches the shape of the Decode method on both
+// github.com/hraban/opus's cgo binding and pure-Go Opus decoders, so
+// either can be plugged in via OpusDecoderFactory.
+type OpusDecoder interface {
+	// Decode decodes one Opus frame from data into pcm, returning how
+	// many samples per channel were written.
+	Decode(data []byte, pcm []int16) (int, error)
+}
+
+// OpusDecoderFactory builds a fresh OpusDecoder for the given sample
+// rate/channel count, mirroring MP3EncoderFactory.
+type OpusDecoderFactory func(sampleRate, channels int) (OpusDecoder, error)
+
+// WithPCMOutput decodes every Opus frame a Transcriber receives and
+// feeds the resulting PCM into source, so the Transcriber's audio can be
+// registered with a Mixer via Mixer.AddSource (NewTranscriberPCMSource
+// returns the PCMSource to register) independent of
+// recognition/recording.
+func WithPCMOutput(source *TranscriberPCMSource) TranscriberOption {
+	return func(t *Transcriber) {
+		t.pcmOutput = source
+	}
+}
+
+// TranscriberPCMSource bridges a Transcriber's incoming Opus frames to a
+// Mixer: it implements PCMSource by decoding each RTP packet's Opus
+// payload as it arrives and buffering the PCM for the next ReadPCM.
+type TranscriberPCMSource struct {
+	decoder  OpusDecoder
+	channels int
+
+	lock sync.Mutex
+	buf  []int16
+}
+
+// NewTranscriberPCMSource builds a TranscriberPCMSource decoding with
+// newDecoder. Pass the result to WithPCMOutput to wire it to a
+// Transcriber, and to Mixer.AddSource to include it in a mix.
+func NewTranscriberPCMSource(sampleRate, channels int, newDecoder OpusDecoderFactory) (*TranscriberPCMSource, error) {
+	decoder, err := newDecoder(sampleRate, channels)
+	if err != nil {
+		return nil, err
+	}
+	return &TranscriberPCMSource{decoder: decoder, channels: channels}, nil
+}
+
+// writeRTP decodes pkt's Opus payload and appends the resulting PCM to
+// the buffer ReadPCM drains from.
+func (s *TranscriberPCMSource) writeRTP(pkt *rtp.Packet) error {
+	pcm := make([]int16, maxOpusFrameSamples)
+	n, err := s.decoder.Decode(pkt.Payload, pcm)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	// Decode reports samples per channel; the interleaved PCM spans
+	// n*channels values.
+	s.buf = append(s.buf, pcm[:n*s.channels]...)
+	s.lock.Unlock()
+	return nil
+}
+
+// ReadPCM implements PCMSource. It never blocks: when nothing has been
+// decoded since the last call it returns (0, nil), which Mixer.WriteTo
+// treats as "this source has nothing this frame" rather than EOF.
+func (s *TranscriberPCMSource) ReadPCM(buf []int16) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	n := copy(buf, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+)
+
+// fakeOpusDecoder "decodes" by returning one fixed PCM sample per byte
+// of Opus payload, which is enough to exercise TranscriberPCMSource's
+// buffering without a real libopus/pure-Go decoder.
+type fakeOpusDecoder struct{}
+
+func (fakeOpusDecoder) Decode(data []byte, pcm []int16) (int, error) {
+	for i := range data {
+		pcm[i] = int16(data[i])
+	}
+	return len(data), nil
+}
+
+func TestTranscriberPCMSourceBuffersDecodedPCM(t *testing.T) {
+	source, err := NewTranscriberPCMSource(48000, 1, func(sampleRate, channels int) (OpusDecoder, error) {
+		return fakeOpusDecoder{}, nil
+	})
+	if err != nil {
+		t.Fatalf("NewTranscriberPCMSource: %v", err)
+	}
+
+	if err := source.writeRTP(&rtp.Packet{Payload: []byte{1, 2, 3}}); err != nil {
+		t.Fatalf("writeRTP: %v", err)
+	}
+	if err := source.writeRTP(&rtp.Packet{Payload: []byte{4, 5}}); err != nil {
+		t.Fatalf("writeRTP: %v", err)
+	}
+
+	buf := make([]int16, 10)
+	n, err := source.ReadPCM(buf)
+	if err != nil {
+		t.Fatalf("ReadPCM: %v", err)
+	}
+	if want := []int16{1, 2, 3, 4, 5}; n != len(want) || !equalInt16s(buf[:n], want) {
+		t.Fatalf("ReadPCM = %v (n=%d), want %v", buf[:n], n, want)
+	}
+
+	n, err = source.ReadPCM(buf)
+	if err != nil || n != 0 {
+		t.Fatalf("ReadPCM on drained source = (%d, %v), want (0, nil)", n, err)
+	}
+}
+
+func equalInt16s(a, b []int16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+	lksdk "github.com/livekit/server-sdk-go"
+	"github.com/pion/webrtc/v3"
+)
+
+// BackpressureMode controls what TranscriberPool does when the merged
+// Results() stream isn't being drained fast enough.
+type BackpressureMode int
+
+const (
+	// BackpressureBlock blocks the producing participant's result loop
+	// until there's room in the merged stream. Simple and lossless, but
+	// a slow consumer stalls every participant's transcription.
+	BackpressureBlock BackpressureMode = iota
+	// BackpressureDropOldest discards the oldest buffered result to make
+	// room for the newest one, favoring freshness over completeness.
+	BackpressureDropOldest
+)
+
+// TranscriberPoolOption configures optional TranscriberPool behavior.
+type TranscriberPoolOption func(*TranscriberPool)
+
+// WithPoolBackpressure sets how the pool behaves when Results() isn't
+// drained fast enough. Defaults to BackpressureBlock.
+func WithPoolBackpressure(mode BackpressureMode) TranscriberPoolOption {
+	return func(p *TranscriberPool) {
+		p.backpressure = mode
+	}
+}
+
+// WithPoolBufferSize sets the merged Results() channel's buffer.
+// Defaults to 32.
+func WithPoolBufferSize(size int) TranscriberPoolOption {
+	return func(p *TranscriberPool) {
+		p.bufferSize = size
+	}
+}
+
+// WithPoolHistoryLimit sets how many finalized utterances Snapshot keeps
+// per speaker. Defaults to 20.
+func WithPoolHistoryLimit(limit int) TranscriberPoolOption {
+	return func(p *TranscriberPool) {
+		p.historyLimit = limit
+	}
+}
+
+// WithPoolTranscriberOptions applies opts to every Transcriber the pool
+// creates, so features like WithVAD, WithBargeIn, and WithRecording can
+// be attached to pooled transcribers, not just standalone ones.
+func WithPoolTranscriberOptions(opts ...TranscriberOption) TranscriberPoolOption {
+	return func(p *TranscriberPool) {
+		p.opts = append(p.opts, opts...)
+	}
+}
+
+// RecognizerFactory builds the SpeechRecognizer backend a new
+// Transcriber should use for a given participant/track, so a pool can,
+// for example, hand each participant their own Google STT stream.
+type RecognizerFactory func(participantIdentity, trackSID string) (SpeechRecognizer, error)
+
+// TranscriberPool manages one Transcriber per remote audio track in a
+// room, keyed by participant identity + track SID, with lifecycle wired
+// to LiveKit's TrackSubscribed/TrackUnsubscribed callbacks. It merges
+// every Transcriber's Results() into a single ordered stream so callers
+// see a diarized transcript instead of one flat text stream per track.
+type TranscriberPool struct {
+	newRecognizer RecognizerFactory
+	language      *Language
+	opts          []TranscriberOption
+
+	backpressure BackpressureMode
+	bufferSize   int
+	historyLimit int
+
+	merged chan RecognizeResult
+
+	lock         sync.Mutex
+	transcribers map[string]*pooledTranscriber
+	history      map[string][]RecognizeResult
+
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+type pooledTranscriber struct {
+	participantIdentity string
+	trackSID            string
+	transcriber         *Transcriber
+	stop                chan struct{}
+}
+
+func poolKey(participantIdentity, trackSID string) string {
+	return participantIdentity + "|" + trackSID
+}
+
+// NewTranscriberPool returns an empty pool. newRecognizer is called once
+// per subscribed audio track to build that track's backend.
+func NewTranscriberPool(newRecognizer RecognizerFactory, language *Language, opts ...TranscriberPoolOption) *TranscriberPool {
+	p := &TranscriberPool{
+		newRecognizer: newRecognizer,
+		language:      language,
+		bufferSize:    32,
+		historyLimit:  20,
+		transcribers:  make(map[string]*pooledTranscriber),
+		history:       make(map[string][]RecognizeResult),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.merged = make(chan RecognizeResult, p.bufferSize)
+	return p
+}
+
+// OnTrackSubscribed is a LiveKit RoomCallback.OnTrackSubscribed handler.
+// It starts a Transcriber for the track if it's an audio track and
+// forwards its results into the pool's merged stream.
+func (p *TranscriberPool) OnTrackSubscribed(track *webrtc.TrackRemote, publication *lksdk.RemoteTrackPublication, rp *lksdk.RemoteParticipant) {
+	if track.Kind() != webrtc.RTPCodecTypeAudio {
+		return
+	}
+
+	recognizer, err := p.newRecognizer(rp.Identity(), track.ID())
+	if err != nil {
+		logger.Errorw("failed to build recognizer for track", err, "participant", rp.Identity(), "track", track.ID())
+		return
+	}
+
+	transcriber, err := NewTranscriber(track.Codec(), recognizer, p.language, p.opts...)
+	if err != nil {
+		logger.Errorw("failed to create transcriber for track", err, "participant", rp.Identity(), "track", track.ID())
+		return
+	}
+
+	pt := &pooledTranscriber{
+		participantIdentity: rp.Identity(),
+		trackSID:            track.ID(),
+		transcriber:         transcriber,
+		stop:                make(chan struct{}),
+	}
+
+	p.lock.Lock()
+	p.transcribers[poolKey(pt.participantIdentity, pt.trackSID)] = pt
+	p.lock.Unlock()
+
+	p.wg.Add(2)
+	go p.forwardRTP(track, pt)
+	go p.forwardResults(pt)
+}
+
+// OnTrackUnsubscribed is a LiveKit RoomCallback.OnTrackUnsubscribed
+// handler. It stops and removes the track's Transcriber.
+func (p *TranscriberPool) OnTrackUnsubscribed(track *webrtc.TrackRemote, publication *lksdk.RemoteTrackPublication, rp *lksdk.RemoteParticipant) {
+	key := poolKey(rp.Identity(), track.ID())
+
+	p.lock.Lock()
+	pt, ok := p.transcribers[key]
+	delete(p.transcribers, key)
+	p.lock.Unlock()
+
+	if !ok {
+		return
+	}
+
+	close(pt.stop)
+	pt.transcriber.Close()
+}
+
+func (p *TranscriberPool) forwardRTP(track *webrtc.TrackRemote, pt *pooledTranscriber) {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-pt.stop:
+			return
+		default:
+		}
+
+		pkt, _, err := track.ReadRTP()
+		if err != nil {
+			return
+		}
+		if err := pt.transcriber.WriteRTP(pkt); err != nil {
+			return
+		}
+	}
+}
+
+func (p *TranscriberPool) forwardResults(pt *pooledTranscriber) {
+	defer p.wg.Done()
+
+	for result := range pt.transcriber.Results() {
+		result.ParticipantIdentity = pt.participantIdentity
+		result.TrackSID = pt.trackSID
+		result.Timestamp = time.Now()
+
+		if result.IsFinal {
+			p.recordHistory(pt.participantIdentity, result)
+		}
+
+		p.publish(result)
+	}
+}
+
+func (p *TranscriberPool) publish(result RecognizeResult) {
+	if p.backpressure == BackpressureBlock {
+		p.merged <- result
+		return
+	}
+
+	for {
+		select {
+		case p.merged <- result:
+			return
+		default:
+			select {
+			case <-p.merged:
+			default:
+			}
+		}
+	}
+}
+
+func (p *TranscriberPool) recordHistory(participantIdentity string, result RecognizeResult) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	utterances := append(p.history[participantIdentity], result)
+	if len(utterances) > p.historyLimit {
+		utterances = utterances[len(utterances)-p.historyLimit:]
+	}
+	p.history[participantIdentity] = utterances
+}
+
+// Results returns the merged, diarized stream of every participant's
+// recognition results, ordered by arrival.
+func (p *TranscriberPool) Results() <-chan RecognizeResult {
+	return p.merged
+}
+
+// Snapshot returns the last n finalized utterances per speaker, keyed by
+// participant identity. Useful for building LLM context from a
+// multi-party call. n <= 0 returns everything buffered.
+func (p *TranscriberPool) Snapshot(n int) map[string][]RecognizeResult {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	snapshot := make(map[string][]RecognizeResult, len(p.history))
+	for identity, utterances := range p.history {
+		if n > 0 && len(utterances) > n {
+			utterances = utterances[len(utterances)-n:]
+		}
+		copied := make([]RecognizeResult, len(utterances))
+		copy(copied, utterances)
+		snapshot[identity] = copied
+	}
+	return snapshot
+}
+
+// Close stops every Transcriber in the pool and closes the merged
+// Results() channel.
+func (p *TranscriberPool) Close() {
+	p.closeOnce.Do(func() {
+		p.lock.Lock()
+		transcribers := make([]*pooledTranscriber, 0, len(p.transcribers))
+		for _, pt := range p.transcribers {
+			transcribers = append(transcribers, pt)
+		}
+		p.transcribers = make(map[string]*pooledTranscriber)
+		p.lock.Unlock()
+
+		for _, pt := range transcribers {
+			close(pt.stop)
+			pt.transcriber.Close()
+		}
+
+		p.wg.Wait()
+		close(p.merged)
+	})
+}
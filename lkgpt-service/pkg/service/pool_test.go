@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+var testOpusCodec = webrtc.RTPCodecParameters{
+	RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: "audio/opus", ClockRate: 48000, Channels: 1},
+}
+
+// fakePoolStream is a RecognizerStream whose results are driven directly
+// by the test, standing in for a live STT backend so pool.forwardResults
+// can be exercised without a network connection. Like the real backends
+// (see recognizer_google_v1.go, recognizer_websocket.go), Recv respects
+// the stream's context so Transcriber.Close() can unblock it.
+type fakePoolStream struct {
+	ctx     context.Context
+	results chan RecognizeResult
+}
+
+func newFakePoolStream(ctx context.Context) *fakePoolStream {
+	return &fakePoolStream{ctx: ctx, results: make(chan RecognizeResult, 8)}
+}
+
+func (s *fakePoolStream) Write(frame []byte) error { return nil }
+
+func (s *fakePoolStream) Recv() (RecognizeResult, error) {
+	select {
+	case r := <-s.results:
+		return r, nil
+	case <-s.ctx.Done():
+		return RecognizeResult{}, context.Canceled
+	}
+}
+
+func (s *fakePoolStream) Close() error { return nil }
+
+// fakePoolRecognizer hands back a single fakePoolStream, captured on
+// startedStream so the test can push results into it after
+// NewTranscriber has started consuming from it.
+type fakePoolRecognizer struct {
+	startedStream chan *fakePoolStream
+}
+
+func newFakePoolRecognizer() *fakePoolRecognizer {
+	return &fakePoolRecognizer{startedStream: make(chan *fakePoolStream, 1)}
+}
+
+func (r *fakePoolRecognizer) StartStream(ctx context.Context, params RecognitionParams) (RecognizerStream, error) {
+	s := newFakePoolStream(ctx)
+	r.startedStream <- s
+	return s, nil
+}
+
+// newTestPooledTranscriber builds a pooledTranscriber backed by a real
+// Transcriber driven by a fake backend, so pool.forwardResults runs the
+// same concurrent path it does in production.
+func newTestPooledTranscriber(t *testing.T, identity, trackSID string) (*pooledTranscriber, *fakePoolStream) {
+	t.Helper()
+
+	recognizer := newFakePoolRecognizer()
+	transcriber, err := NewTranscriber(testOpusCodec, recognizer, nil)
+	if err != nil {
+		t.Fatalf("NewTranscriber: %v", err)
+	}
+
+	var stream *fakePoolStream
+	select {
+	case stream = <-recognizer.startedStream:
+	case <-time.After(time.Second):
+		t.Fatal("transcriber never started a stream")
+	}
+
+	return &pooledTranscriber{
+		participantIdentity: identity,
+		trackSID:            trackSID,
+		transcriber:         transcriber,
+		stop:                make(chan struct{}),
+	}, stream
+}
+
+func readPoolResult(t *testing.T, p *TranscriberPool) RecognizeResult {
+	t.Helper()
+	select {
+	case r := <-p.Results():
+		return r
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a merged result")
+		return RecognizeResult{}
+	}
+}
+
+func TestTranscriberPoolBackpressureBlockDeliversEveryResult(t *testing.T) {
+	p := NewTranscriberPool(nil, nil, WithPoolBackpressure(BackpressureBlock), WithPoolBufferSize(1))
+	defer p.Close()
+
+	pt, stream := newTestPooledTranscriber(t, "alice", "track1")
+	defer pt.transcriber.Close()
+	p.wg.Add(1)
+	go p.forwardResults(pt)
+
+	stream.results <- RecognizeResult{Text: "first", IsFinal: true}
+	stream.results <- RecognizeResult{Text: "second", IsFinal: true}
+
+	if got := readPoolResult(t, p).Text; got != "first" {
+		t.Fatalf("first result = %q, want %q", got, "first")
+	}
+	if got := readPoolResult(t, p).Text; got != "second" {
+		t.Fatalf("second result = %q, want %q", got, "second")
+	}
+}
+
+func TestTranscriberPoolBackpressureDropOldestDropsOlderResult(t *testing.T) {
+	p := NewTranscriberPool(nil, nil, WithPoolBackpressure(BackpressureDropOldest), WithPoolBufferSize(1))
+	defer p.Close()
+
+	pt, stream := newTestPooledTranscriber(t, "alice", "track1")
+	defer pt.transcriber.Close()
+	p.wg.Add(1)
+	go p.forwardResults(pt)
+
+	stream.results <- RecognizeResult{Text: "stale", IsFinal: true}
+	stream.results <- RecognizeResult{Text: "fresh", IsFinal: true}
+
+	// Give both results time to flow through before we look: with a
+	// buffer of 1, the second publish should have evicted the first
+	// rather than blocking behind it.
+	time.Sleep(50 * time.Millisecond)
+
+	if got := readPoolResult(t, p).Text; got != "fresh" {
+		t.Fatalf("merged result = %q, want %q (stale one should have been dropped)", got, "fresh")
+	}
+
+	select {
+	case r := <-p.Results():
+		t.Fatalf("unexpected extra result %+v, buffer should only ever hold the newest", r)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestTranscriberPoolSnapshotTrimsToHistoryLimit(t *testing.T) {
+	p := NewTranscriberPool(nil, nil, WithPoolHistoryLimit(2))
+	defer p.Close()
+
+	p.recordHistory("alice", RecognizeResult{Text: "one"})
+	p.recordHistory("alice", RecognizeResult{Text: "two"})
+	p.recordHistory("alice", RecognizeResult{Text: "three"})
+
+	snapshot := p.Snapshot(0)
+	got := snapshot["alice"]
+	if want := []string{"two", "three"}; len(got) != len(want) || got[0].Text != want[0] || got[1].Text != want[1] {
+		t.Fatalf("Snapshot(0)[alice] = %+v, want texts %v", got, want)
+	}
+
+	narrower := p.Snapshot(1)
+	if got := narrower["alice"]; len(got) != 1 || got[0].Text != "three" {
+		t.Fatalf("Snapshot(1)[alice] = %+v, want [three]", got)
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrStreamExhausted is returned by RecognizerStream.Recv when the
+// backend has reached a hard limit on how long a single stream may stay
+// open (e.g. Google STT v1's five minute cap). The caller should open a
+// new stream via SpeechRecognizer.StartStream and keep going.
+var ErrStreamExhausted = errors.New("service: speech stream exhausted, open a new one")
+
+// RecognitionParams configures a streaming recognition session. Backends
+// only look at the fields they understand and ignore the rest, so it's
+// safe to share one RecognitionParams across different SpeechRecognizer
+// implementations.
+type RecognitionParams struct {
+	SampleRate int
+	Channels   int
+	Language   *Language
+}
+
+// SpeechRecognizer is implemented by every speech-to-text backend we
+// support (Google STT v1/v2, OpenAI Whisper, WebSocket-based vendors
+// like Deepgram or Azure). A Transcriber only ever talks to a
+// SpeechRecognizer, never to a vendor SDK directly, so swapping backends
+// is a matter of passing a different one to NewTranscriber.
+type SpeechRecognizer interface {
+	// StartStream opens a new streaming recognition session.
+	StartStream(ctx context.Context, params RecognitionParams) (RecognizerStream, error)
+}
+
+// RecognizerStream is a single streaming recognition session returned by
+// a SpeechRecognizer. Callers write raw RTP frames and read back results
+// until Recv returns an error.
+type RecognizerStream interface {
+	// Write sends a marshaled RTP packet to the backend. Implementations
+	// that need the raw media payload (PCM/Opus) rather than RTP framing
+	// unmarshal it themselves.
+	Write(frame []byte) error
+	// Recv blocks until the next result is available, returns
+	// ErrStreamExhausted when the stream needs to be recreated, or
+	// context.Canceled when the caller stopped it.
+	Recv() (RecognizeResult, error)
+	// Close terminates the stream and releases its resources.
+	Close() error
+}
+
+// RecognizeResult is a single partial or final transcription.
+type RecognizeResult struct {
+	Error   error
+	Text    string
+	IsFinal bool
+
+	// Confidence is only populated by backends that report it (currently
+	// Google STT v2 and the WebSocket backend). Words is only populated by
+	// backends that report word-level timing and confidence (currently
+	// Google STT v2).
+	Confidence float32
+	Words      []WordInfo
+
+	// SegmentID, StartedAt and EndedAt identify the VAD-bounded
+	// utterance this result belongs to. They're zero-valued when the
+	// Transcriber has neither a VoiceActivityDetector nor a recording
+	// configured.
+	SegmentID int
+	StartedAt time.Time
+	EndedAt   time.Time
+
+	// ParticipantIdentity, TrackSID and Timestamp are filled in by
+	// TranscriberPool when merging multiple participants' results into
+	// one diarized stream. They're zero-valued for a standalone
+	// Transcriber.
+	ParticipantIdentity string
+	TrackSID            string
+	Timestamp           time.Time
+}
+
+// WordInfo is a single word-level timing/confidence entry within a final
+// RecognizeResult.
+type WordInfo struct {
+	Word       string
+	StartTime  time.Duration
+	EndTime    time.Duration
+	Confidence float32
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	stt "cloud.google.com/go/speech/apiv1"
+	sttpb "cloud.google.com/go/speech/apiv1/speechpb"
+	"github.com/livekit/protocol/logger"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3/pkg/media/oggwriter"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GoogleV1Recognizer implements SpeechRecognizer on top of Google Cloud
+// Speech-to-Text v1. v1 can't decode Opus on its own, so every stream
+// serializes the RTP packets it's given into an Ogg/Opus container
+// before forwarding the bytes to the API.
+type GoogleV1Recognizer struct {
+	client *stt.Client
+}
+
+// NewGoogleV1Recognizer wraps an existing v1 speech client.
+func NewGoogleV1Recognizer(client *stt.Client) *GoogleV1Recognizer {
+	return &GoogleV1Recognizer{client: client}
+}
+
+func (r *GoogleV1Recognizer) StartStream(ctx context.Context, params RecognitionParams) (RecognizerStream, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+
+	grpcStream, err := r.client.StreamingRecognize(streamCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	config := &sttpb.RecognitionConfig{
+		Model: "command_and_search",
+		Adaptation: &sttpb.SpeechAdaptation{
+			PhraseSets: []*sttpb.PhraseSet{
+				{
+					Phrases: []*sttpb.PhraseSet_Phrase{
+						{Value: "${hello} ${gpt}"},
+						{Value: "${gpt}"},
+						{Value: "Hey ${gpt}"},
+					},
+					Boost: 19,
+				},
+			},
+			CustomClasses: []*sttpb.CustomClass{
+				{
+					CustomClassId: "hello",
+					Items: []*sttpb.CustomClass_ClassItem{
+						{Value: "Hi"},
+						{Value: "Hello"},
+						{Value: "Hey"},
+					},
+				},
+				{
+					CustomClassId: "gpt",
+					Items: []*sttpb.CustomClass_ClassItem{
+						{Value: "Kit"},
+						{Value: "KITT"},
+						{Value: "GPT"},
+						{Value: "Live Kit"},
+						{Value: "Live GPT"},
+						{Value: "LiveKit"},
+						{Value: "LiveGPT"},
+						{Value: "Live-Kit"},
+						{Value: "Live-GPT"},
+					},
+				},
+			},
+		},
+		UseEnhanced:       true,
+		Encoding:          sttpb.RecognitionConfig_OGG_OPUS,
+		SampleRateHertz:   int32(params.SampleRate),
+		AudioChannelCount: int32(params.Channels),
+		LanguageCode:      params.Language.Code,
+	}
+
+	if err := grpcStream.Send(&sttpb.StreamingRecognizeRequest{
+		StreamingRequest: &sttpb.StreamingRecognizeRequest_StreamingConfig{
+			StreamingConfig: &sttpb.StreamingRecognitionConfig{
+				InterimResults: true,
+				Config:         config,
+			},
+		},
+	}); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	oggReader, oggWriter := io.Pipe()
+	oggSerializer, err := oggwriter.NewWith(oggWriter, uint32(params.SampleRate), uint16(params.Channels))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &googleV1Stream{
+		cancel:        cancel,
+		grpcStream:    grpcStream,
+		oggWriter:     oggWriter,
+		oggReader:     oggReader,
+		oggSerializer: oggSerializer,
+	}
+	go s.forwardAudio()
+	return s, nil
+}
+
+// googleV1Stream owns the Ogg/Opus pipe plumbing that used to live
+// directly on Transcriber: RTP packets go in one end, Ogg/Opus bytes
+// come out the other and get forwarded as AudioContent.
+type googleV1Stream struct {
+	cancel     context.CancelFunc
+	grpcStream sttpb.Speech_StreamingRecognizeClient
+
+	oggWriter     *io.PipeWriter
+	oggReader     *io.PipeReader
+	oggSerializer *oggwriter.OggWriter
+
+	closeOnce sync.Once
+}
+
+func (s *googleV1Stream) Write(frame []byte) error {
+	pkt := &rtp.Packet{}
+	if err := pkt.Unmarshal(frame); err != nil {
+		return err
+	}
+	return s.oggSerializer.WriteRTP(pkt)
+}
+
+func (s *googleV1Stream) forwardAudio() {
+	buf := make([]byte, 1024)
+	for {
+		n, err := s.oggReader.Read(buf)
+		if err != nil {
+			if err != io.EOF {
+				logger.Errorw("failed to read from ogg reader", err)
+			}
+			return
+		}
+
+		if n <= 0 {
+			continue
+		}
+
+		logger.Debugw("sending audio content to speech stream", "n", n)
+		if err := s.grpcStream.Send(&sttpb.StreamingRecognizeRequest{
+			StreamingRequest: &sttpb.StreamingRecognizeRequest_AudioContent{
+				AudioContent: buf[:n],
+			},
+		}); err != nil {
+			if err != io.EOF {
+				logger.Errorw("failed to send audio content to speech stream", err)
+			}
+			return
+		}
+	}
+}
+
+func (s *googleV1Stream) Recv() (RecognizeResult, error) {
+	for {
+		resp, err := s.grpcStream.Recv()
+		if err != nil {
+			if st, ok := status.FromError(err); ok {
+				switch st.Code() {
+				case codes.OutOfRange:
+					// Maximum speech length exceeded.
+					return RecognizeResult{}, ErrStreamExhausted
+				case codes.Canceled:
+					return RecognizeResult{}, context.Canceled
+				}
+			}
+			return RecognizeResult{}, err
+		}
+
+		if resp.Error != nil {
+			continue
+		}
+
+		var sb strings.Builder
+		final := false
+		for _, result := range resp.Results {
+			alt := result.Alternatives[0]
+			text := alt.Transcript
+			sb.WriteString(text)
+
+			if result.IsFinal {
+				sb.Reset()
+				sb.WriteString(text)
+				final = true
+				break
+			}
+		}
+
+		return RecognizeResult{Text: sb.String(), IsFinal: final}, nil
+	}
+}
+
+func (s *googleV1Stream) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		// Unblocks an in-flight grpcStream.Recv(); Recv surfaces this as
+		// codes.Canceled.
+		s.cancel()
+		err = s.oggWriter.Close()
+	})
+	return err
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	sttv2 "cloud.google.com/go/speech/apiv2"
+	sttv2pb "cloud.google.com/go/speech/apiv2/speechpb"
+	"github.com/pion/rtp"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GoogleV2Config configures a GoogleV2Recognizer backend.
+type GoogleV2Config struct {
+	Project  string
+	Location string
+
+	// RecognizerID names a pre-created Recognizer resource
+	// (projects/{Project}/locations/{Location}/recognizers/{RecognizerID}).
+	// When empty, StartStream falls back to an inline RecognitionConfig
+	// on the implicit "_" recognizer instead.
+	RecognizerID string
+
+	Model         string   // e.g. "latest_long", "chirp", "chirp_2"
+	LanguageCodes []string // e.g. []string{"en-US"}; v2 supports multiple
+
+	EnableAutomaticPunctuation bool
+	EnableWordTimeOffsets      bool
+}
+
+// GoogleV2Recognizer implements SpeechRecognizer on top of Google Cloud
+// Speech-to-Text v2. Unlike v1, v2 can auto-decode Opus itself, so
+// streams here forward the raw Opus payload straight from each RTP
+// packet instead of muxing it into an Ogg container first.
+type GoogleV2Recognizer struct {
+	client *sttv2.Client
+	config GoogleV2Config
+}
+
+// NewGoogleV2Recognizer dials the regional v2 endpoint for cfg.Location
+// and returns a backend bound to it.
+func NewGoogleV2Recognizer(ctx context.Context, cfg GoogleV2Config) (*GoogleV2Recognizer, error) {
+	if cfg.Location == "" {
+		return nil, errors.New("service: GoogleV2Config.Location is required")
+	}
+
+	endpoint := fmt.Sprintf("%s-speech.googleapis.com:443", cfg.Location)
+	client, err := sttv2.NewClient(ctx, option.WithEndpoint(endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	return &GoogleV2Recognizer{client: client, config: cfg}, nil
+}
+
+func (r *GoogleV2Recognizer) recognizerName() string {
+	id := r.config.RecognizerID
+	if id == "" {
+		id = "_"
+	}
+	return fmt.Sprintf("projects/%s/locations/%s/recognizers/%s", r.config.Project, r.config.Location, id)
+}
+
+func (r *GoogleV2Recognizer) StartStream(ctx context.Context, params RecognitionParams) (RecognizerStream, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+
+	grpcStream, err := r.client.StreamingRecognize(streamCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	streamingConfig := &sttv2pb.StreamingRecognitionConfig{
+		StreamingFeatures: &sttv2pb.StreamingRecognitionFeatures{
+			InterimResults: true,
+		},
+	}
+
+	// A pre-created Recognizer already carries its own RecognitionConfig;
+	// only fall back to an inline one when the caller didn't supply one.
+	if r.config.RecognizerID == "" {
+		streamingConfig.Config = &sttv2pb.RecognitionConfig{
+			DecodingConfig: &sttv2pb.RecognitionConfig_AutoDecodingConfig{
+				AutoDecodingConfig: &sttv2pb.AutoDetectDecodingConfig{},
+			},
+			Model:         r.config.Model,
+			LanguageCodes: r.config.LanguageCodes,
+			Features: &sttv2pb.RecognitionFeatures{
+				EnableAutomaticPunctuation: r.config.EnableAutomaticPunctuation,
+				EnableWordTimeOffsets:      r.config.EnableWordTimeOffsets,
+			},
+		}
+	}
+
+	if err := grpcStream.Send(&sttv2pb.StreamingRecognizeRequest{
+		Recognizer: r.recognizerName(),
+		StreamingRequest: &sttv2pb.StreamingRecognizeRequest_StreamingConfig{
+			StreamingConfig: streamingConfig,
+		},
+	}); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return &googleV2Stream{cancel: cancel, grpcStream: grpcStream, reportWords: r.config.EnableWordTimeOffsets}, nil
+}
+
+type googleV2Stream struct {
+	cancel      context.CancelFunc
+	grpcStream  sttv2pb.Speech_StreamingRecognizeClient
+	reportWords bool
+}
+
+func (s *googleV2Stream) Write(frame []byte) error {
+	pkt := &rtp.Packet{}
+	if err := pkt.Unmarshal(frame); err != nil {
+		return err
+	}
+
+	return s.grpcStream.Send(&sttv2pb.StreamingRecognizeRequest{
+		StreamingRequest: &sttv2pb.StreamingRecognizeRequest_Audio{
+			Audio: pkt.Payload,
+		},
+	})
+}
+
+func (s *googleV2Stream) Recv() (RecognizeResult, error) {
+	for {
+		resp, err := s.grpcStream.Recv()
+		if err != nil {
+			if st, ok := status.FromError(err); ok {
+				switch st.Code() {
+				case codes.OutOfRange:
+					return RecognizeResult{}, ErrStreamExhausted
+				case codes.Canceled:
+					return RecognizeResult{}, context.Canceled
+				}
+			}
+			return RecognizeResult{}, err
+		}
+
+		results := resp.GetResults()
+		if len(results) == 0 {
+			continue
+		}
+
+		var result RecognizeResult
+		var sb strings.Builder
+		for _, r := range results {
+			alt := r.Alternatives[0]
+			sb.WriteString(alt.Transcript)
+			result.Confidence = alt.Confidence
+
+			if r.IsFinal {
+				sb.Reset()
+				sb.WriteString(alt.Transcript)
+				result.IsFinal = true
+				if s.reportWords {
+					result.Words = wordsFromAlternative(alt)
+				}
+				break
+			}
+		}
+
+		result.Text = sb.String()
+		return result, nil
+	}
+}
+
+func (s *googleV2Stream) Close() error {
+	// Unblocks an in-flight grpcStream.Recv(); Recv surfaces this as
+	// codes.Canceled.
+	defer s.cancel()
+	return s.grpcStream.CloseSend()
+}
+
+func wordsFromAlternative(alt *sttv2pb.SpeechRecognitionAlternative) []WordInfo {
+	if len(alt.Words) == 0 {
+		return nil
+	}
+
+	words := make([]WordInfo, 0, len(alt.Words))
+	for _, w := range alt.Words {
+		words = append(words, WordInfo{
+			Word:       w.Word,
+			StartTime:  w.StartOffset.AsDuration(),
+			EndTime:    w.EndOffset.AsDuration(),
+			Confidence: w.Confidence,
+		})
+	}
+	return words
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/pion/rtp"
+)
+
+// WebSocketConfig configures a WebSocketRecognizer backend. It covers
+// any vendor that streams raw audio over a WebSocket and replies with
+// JSON messages shaped like Deepgram's or Azure's real-time
+// transcription APIs:
+//
+//	{"is_final": true, "channel": {"alternatives": [{"transcript": "...", "confidence": 0.9}]}}
+type WebSocketConfig struct {
+	URL     string
+	Headers http.Header
+}
+
+// WebSocketRecognizer implements SpeechRecognizer against a
+// WebSocket-based streaming STT vendor (Deepgram, Azure Speech, ...).
+type WebSocketRecognizer struct {
+	config WebSocketConfig
+}
+
+// NewWebSocketRecognizer builds a WebSocketRecognizer from cfg.
+func NewWebSocketRecognizer(cfg WebSocketConfig) *WebSocketRecognizer {
+	return &WebSocketRecognizer{config: cfg}
+}
+
+func (r *WebSocketRecognizer) StartStream(ctx context.Context, params RecognitionParams) (RecognizerStream, error) {
+	u, err := url.Parse(r.config.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+
+	conn, _, err := websocket.DefaultDialer.DialContext(streamCtx, u.String(), r.config.Headers)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &webSocketStream{ctx: streamCtx, cancel: cancel, conn: conn}
+	go s.closeOnDone()
+	return s, nil
+}
+
+type webSocketStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	conn   *websocket.Conn
+
+	closeOnce sync.Once
+}
+
+// closeOnDone closes the underlying connection as soon as ctx is
+// canceled, unblocking an in-flight ReadMessage in Recv -- otherwise a
+// remote that never sends a close frame would hang Recv (and therefore
+// Transcriber.Close) forever.
+func (s *webSocketStream) closeOnDone() {
+	<-s.ctx.Done()
+	s.conn.Close()
+}
+
+func (s *webSocketStream) Write(frame []byte) error {
+	pkt := &rtp.Packet{}
+	if err := pkt.Unmarshal(frame); err != nil {
+		return err
+	}
+	return s.conn.WriteMessage(websocket.BinaryMessage, pkt.Payload)
+}
+
+type webSocketMessage struct {
+	IsFinal bool `json:"is_final"`
+	Channel struct {
+		Alternatives []struct {
+			Transcript string  `json:"transcript"`
+			Confidence float32 `json:"confidence"`
+		} `json:"alternatives"`
+	} `json:"channel"`
+}
+
+func (s *webSocketStream) Recv() (RecognizeResult, error) {
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				// closeOnDone closed the conn because the stream was stopped.
+				return RecognizeResult{}, context.Canceled
+			}
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return RecognizeResult{}, context.Canceled
+			}
+			return RecognizeResult{}, err
+		}
+
+		var msg webSocketMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		if len(msg.Channel.Alternatives) == 0 {
+			continue
+		}
+
+		alt := msg.Channel.Alternatives[0]
+		return RecognizeResult{
+			Text:       alt.Transcript,
+			IsFinal:    msg.IsFinal,
+			Confidence: alt.Confidence,
+		}, nil
+	}
+}
+
+func (s *webSocketStream) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.cancel()
+		err = s.conn.Close()
+	})
+	return err
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3/pkg/media/oggwriter"
+)
+
+const whisperTranscriptionURL = "https://api.openai.com/v1/audio/transcriptions"
+
+// WhisperConfig configures a WhisperRecognizer backend.
+type WhisperConfig struct {
+	APIKey string
+	Model  string // defaults to "whisper-1"
+
+	// ChunkDuration is how much audio is buffered before each
+	// transcription request, since Whisper has no streaming endpoint.
+	ChunkDuration time.Duration
+}
+
+// WhisperRecognizer implements SpeechRecognizer against the OpenAI
+// Whisper transcription API. Whisper isn't a streaming API, so each
+// stream buffers incoming frames as Ogg/Opus and flushes them as
+// fixed-duration chunks, each producing one final RecognizeResult.
+type WhisperRecognizer struct {
+	config     WhisperConfig
+	httpClient *http.Client
+}
+
+// NewWhisperRecognizer builds a WhisperRecognizer from cfg, applying
+// defaults for any field left unset.
+func NewWhisperRecognizer(cfg WhisperConfig) *WhisperRecognizer {
+	if cfg.Model == "" {
+		cfg.Model = "whisper-1"
+	}
+	if cfg.ChunkDuration == 0 {
+		cfg.ChunkDuration = 5 * time.Second
+	}
+
+	return &WhisperRecognizer{config: cfg, httpClient: &http.Client{}}
+}
+
+func (r *WhisperRecognizer) StartStream(ctx context.Context, params RecognitionParams) (RecognizerStream, error) {
+	s := &whisperStream{
+		ctx:     ctx,
+		config:  r.config,
+		client:  r.httpClient,
+		params:  params,
+		buf:     &bytes.Buffer{},
+		results: make(chan RecognizeResult, 8),
+		closeCh: make(chan struct{}),
+	}
+	if err := s.resetSerializer(); err != nil {
+		return nil, err
+	}
+
+	go s.flushLoop()
+	return s, nil
+}
+
+type whisperStream struct {
+	ctx    context.Context
+	config WhisperConfig
+	client *http.Client
+	params RecognitionParams
+
+	mu         sync.Mutex
+	buf        *bytes.Buffer
+	serializer *oggwriter.OggWriter
+
+	results chan RecognizeResult
+	closeCh chan struct{}
+}
+
+func (s *whisperStream) resetSerializer() error {
+	s.buf.Reset()
+	serializer, err := oggwriter.NewWith(s.buf, uint32(s.params.SampleRate), uint16(s.params.Channels))
+	if err != nil {
+		return err
+	}
+	s.serializer = serializer
+	return nil
+}
+
+func (s *whisperStream) Write(frame []byte) error {
+	pkt := &rtp.Packet{}
+	if err := pkt.Unmarshal(frame); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.serializer.WriteRTP(pkt)
+}
+
+func (s *whisperStream) flushLoop() {
+	ticker := time.NewTicker(s.config.ChunkDuration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.flush(); err != nil {
+				s.results <- RecognizeResult{Error: err}
+			}
+		}
+	}
+}
+
+func (s *whisperStream) flush() error {
+	s.mu.Lock()
+	if s.buf.Len() == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	audio := make([]byte, s.buf.Len())
+	copy(audio, s.buf.Bytes())
+	err := s.resetSerializer()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	text, err := s.transcribe(audio)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		return nil
+	}
+
+	s.results <- RecognizeResult{Text: text, IsFinal: true}
+	return nil
+}
+
+func (s *whisperStream) transcribe(audio []byte) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	part, err := w.CreateFormFile("file", "chunk.ogg")
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(audio); err != nil {
+		return "", err
+	}
+	if err := w.WriteField("model", s.config.Model); err != nil {
+		return "", err
+	}
+	if s.params.Language != nil {
+		if err := w.WriteField("language", s.params.Language.Code); err != nil {
+			return "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, whisperTranscriptionURL, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.config.APIKey))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("whisper: transcription request failed: %s: %s", resp.Status, respBody)
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	return result.Text, nil
+}
+
+func (s *whisperStream) Recv() (RecognizeResult, error) {
+	select {
+	case result, ok := <-s.results:
+		if !ok {
+			return RecognizeResult{}, context.Canceled
+		}
+		return result, nil
+	case <-s.ctx.Done():
+		return RecognizeResult{}, context.Canceled
+	}
+}
+
+func (s *whisperStream) Close() error {
+	close(s.closeCh)
+	return nil
+}
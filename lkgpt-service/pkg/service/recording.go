@@ -0,0 +1,174 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3/pkg/media/oggwriter"
+)
+
+// RecordingSink tees the Ogg/Opus bytes a Transcriber records to a
+// durable destination (local file, S3, GCS, ...), independent of
+// transcription: recording keeps going even if the recognizer stream
+// restarts.
+type RecordingSink interface {
+	// Chunk opens a writer for a new recording segment belonging to
+	// sessionID. segmentID matches the RecognizeResult.SegmentID of the
+	// transcript entries produced during that segment.
+	Chunk(sessionID string, segmentID int) (io.WriteCloser, error)
+	// Manifest opens a writer for sessionID's manifest file.
+	Manifest(sessionID string) (io.WriteCloser, error)
+}
+
+// ManifestSegment ties one recorded audio segment to the transcript
+// entries produced while it was being recorded, so the recording can be
+// replayed with captions.
+type ManifestSegment struct {
+	SegmentID  int               `json:"segmentId"`
+	StartedAt  time.Time         `json:"startedAt"`
+	EndedAt    time.Time         `json:"endedAt"`
+	Transcript []RecognizeResult `json:"transcript"`
+}
+
+// Manifest is the JSON document written to a session's manifest file.
+type Manifest struct {
+	SessionID string            `json:"sessionId"`
+	Segments  []ManifestSegment `json:"segments"`
+}
+
+// WithRecording tees the Ogg/Opus stream a Transcriber builds from RTP
+// packets into sink, keyed by sessionID. Segments roll over on the same
+// SegmentID boundaries RecognizeResults are stamped with -- a VAD
+// boundary when WithVAD is also set, and, with or without VAD, whenever
+// the current segment has run for maxSegmentDuration (0 disables
+// duration-based rollover).
+func WithRecording(sink RecordingSink, sessionID string, maxSegmentDuration time.Duration) TranscriberOption {
+	return func(t *Transcriber) {
+		t.recording = &recording{
+			sink:               sink,
+			sessionID:          sessionID,
+			maxSegmentDuration: maxSegmentDuration,
+			currentSegment:     t.currentSegment,
+			advanceSegment:     t.startNewSegment,
+			openSegment:        -1,
+		}
+	}
+}
+
+// recording holds a Transcriber's recording state. It's nil unless
+// WithRecording was passed to NewTranscriber. It never maintains its own
+// segment counter: currentSegment/advanceSegment are the Transcriber's,
+// so an audio segment and the RecognizeResults produced during it
+// always share the same SegmentID.
+type recording struct {
+	sink               RecordingSink
+	sessionID          string
+	maxSegmentDuration time.Duration
+
+	currentSegment func() (int, time.Time)
+	advanceSegment func() (int, time.Time)
+
+	lock        sync.Mutex
+	openSegment int // segment id backing writer/serializer; -1 when none open
+	writer      io.WriteCloser
+	serializer  *oggwriter.OggWriter
+	manifest    Manifest
+}
+
+func (r *recording) write(pkt *rtp.Packet, sampleRate uint32, channels uint16) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	id, started := r.currentSegment()
+	if r.serializer != nil && r.maxSegmentDuration > 0 && time.Since(started) >= r.maxSegmentDuration {
+		id, started = r.advanceSegment()
+	}
+
+	if r.serializer == nil || id != r.openSegment {
+		if err := r.rolloverLocked(id, started, sampleRate, channels); err != nil {
+			return err
+		}
+	}
+
+	return r.serializer.WriteRTP(pkt)
+}
+
+// rolloverLocked closes whatever segment is currently open (if any) and
+// opens id as the new one.
+func (r *recording) rolloverLocked(id int, startedAt time.Time, sampleRate uint32, channels uint16) error {
+	r.closeSegmentLocked()
+
+	w, err := r.sink.Chunk(r.sessionID, id)
+	if err != nil {
+		return err
+	}
+
+	serializer, err := oggwriter.NewWith(w, sampleRate, channels)
+	if err != nil {
+		w.Close()
+		return err
+	}
+
+	r.writer = w
+	r.serializer = serializer
+	r.openSegment = id
+	r.manifest.Segments = append(r.manifest.Segments, ManifestSegment{SegmentID: id, StartedAt: startedAt})
+	return nil
+}
+
+func (r *recording) closeSegmentLocked() {
+	if r.serializer == nil {
+		return
+	}
+
+	if n := len(r.manifest.Segments); n > 0 {
+		r.manifest.Segments[n-1].EndedAt = time.Now()
+	}
+
+	r.serializer.Close()
+	r.writer.Close()
+	r.serializer = nil
+	r.writer = nil
+}
+
+// recordTranscript attaches result to the manifest segment it was
+// actually produced during, looked up by SegmentID rather than assumed
+// to be whichever segment is currently open -- a final result typically
+// arrives after STT round-trip latency, by which point recording may
+// already have rolled over to the next segment.
+func (r *recording) recordTranscript(result RecognizeResult) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i := range r.manifest.Segments {
+		if r.manifest.Segments[i].SegmentID == result.SegmentID {
+			r.manifest.Segments[i].Transcript = append(r.manifest.Segments[i].Transcript, result)
+			return
+		}
+	}
+
+	logger.Debugw("no recorded segment for transcript result, dropping from manifest", "segmentId", result.SegmentID)
+}
+
+func (r *recording) close() {
+	r.lock.Lock()
+	r.closeSegmentLocked()
+	r.manifest.SessionID = r.sessionID
+	manifest := r.manifest
+	r.lock.Unlock()
+
+	w, err := r.sink.Manifest(r.sessionID)
+	if err != nil {
+		logger.Errorw("failed to open manifest writer", err, "session", r.sessionID)
+		return
+	}
+	defer w.Close()
+
+	if err := json.NewEncoder(w).Encode(manifest); err != nil {
+		logger.Errorw("failed to write recording manifest", err, "session", r.sessionID)
+	}
+}
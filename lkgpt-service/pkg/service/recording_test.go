@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// nopWriteCloser adapts a bytes.Buffer (or io.Discard) to io.WriteCloser
+// so fakeRecordingSink doesn't need real files.
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// fakeRecordingSink records every chunk/manifest writer it was asked to
+// open, so a test can inspect segment boundaries and the final manifest
+// without touching disk.
+type fakeRecordingSink struct {
+	chunks   []int
+	manifest bytes.Buffer
+}
+
+func (s *fakeRecordingSink) Chunk(sessionID string, segmentID int) (io.WriteCloser, error) {
+	s.chunks = append(s.chunks, segmentID)
+	return nopWriteCloser{io.Discard}, nil
+}
+
+func (s *fakeRecordingSink) Manifest(sessionID string) (io.WriteCloser, error) {
+	return nopWriteCloser{&s.manifest}, nil
+}
+
+func newTestRecording(sink RecordingSink) *recording {
+	segmentID := 0
+	segStart := time.Now()
+	return &recording{
+		sink:      sink,
+		sessionID: "sess",
+		currentSegment: func() (int, time.Time) {
+			return segmentID, segStart
+		},
+		advanceSegment: func() (int, time.Time) {
+			segmentID++
+			segStart = time.Now()
+			return segmentID, segStart
+		},
+		openSegment: -1,
+	}
+}
+
+func TestRecordingWriteRollsOverOnSegmentChange(t *testing.T) {
+	sink := &fakeRecordingSink{}
+	r := newTestRecording(sink)
+
+	pkt := &rtp.Packet{Payload: []byte{0xf8, 0xff, 0xfe}}
+	if err := r.write(pkt, 48000, 1); err != nil {
+		t.Fatalf("write segment 0: %v", err)
+	}
+	if err := r.write(pkt, 48000, 1); err != nil {
+		t.Fatalf("write segment 0 again: %v", err)
+	}
+	if len(sink.chunks) != 1 {
+		t.Fatalf("opened %d chunks for one segment, want 1", len(sink.chunks))
+	}
+
+	r.advanceSegment()
+	if err := r.write(pkt, 48000, 1); err != nil {
+		t.Fatalf("write segment 1: %v", err)
+	}
+
+	if want := []int{0, 1}; !equalInts(sink.chunks, want) {
+		t.Fatalf("chunks opened = %v, want %v", sink.chunks, want)
+	}
+	if len(r.manifest.Segments) != 2 {
+		t.Fatalf("manifest has %d segments, want 2", len(r.manifest.Segments))
+	}
+}
+
+func TestRecordingRecordTranscriptAttachesBySegmentID(t *testing.T) {
+	sink := &fakeRecordingSink{}
+	r := newTestRecording(sink)
+
+	pkt := &rtp.Packet{Payload: []byte{0xf8, 0xff, 0xfe}}
+	r.write(pkt, 48000, 1)
+	r.advanceSegment()
+	r.write(pkt, 48000, 1)
+
+	// The final transcript for segment 0 arrives late, after recording
+	// has already rolled over to segment 1.
+	r.recordTranscript(RecognizeResult{SegmentID: 0, Text: "hello", IsFinal: true})
+	r.recordTranscript(RecognizeResult{SegmentID: 1, Text: "world", IsFinal: true})
+
+	if got := r.manifest.Segments[0].Transcript; len(got) != 1 || got[0].Text != "hello" {
+		t.Fatalf("segment 0 transcript = %+v, want [hello]", got)
+	}
+	if got := r.manifest.Segments[1].Transcript; len(got) != 1 || got[0].Text != "world" {
+		t.Fatalf("segment 1 transcript = %+v, want [world]", got)
+	}
+}
+
+func TestRecordingRecordTranscriptDropsUnknownSegment(t *testing.T) {
+	sink := &fakeRecordingSink{}
+	r := newTestRecording(sink)
+
+	pkt := &rtp.Packet{Payload: []byte{0xf8, 0xff, 0xfe}}
+	r.write(pkt, 48000, 1)
+
+	// No panic, no crash: a result for a segment that was never opened
+	// (or has since been pruned) is simply dropped from the manifest.
+	r.recordTranscript(RecognizeResult{SegmentID: 7, Text: "orphan", IsFinal: true})
+
+	if len(r.manifest.Segments[0].Transcript) != 0 {
+		t.Fatalf("expected no transcript attached, got %+v", r.manifest.Segments[0].Transcript)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
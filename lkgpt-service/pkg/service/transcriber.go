@@ -3,66 +3,96 @@ package service
 import (
 	"context"
 	"errors"
-	"io"
 	"strings"
 	"sync"
+	"time"
 
-	stt "cloud.google.com/go/speech/apiv1"
-	sttpb "cloud.google.com/go/speech/apiv1/speechpb"
 	"github.com/livekit/protocol/logger"
 	"github.com/livekit/server-sdk-go/pkg/samplebuilder"
 	"github.com/pion/rtp"
 	"github.com/pion/rtp/codecs"
 	"github.com/pion/webrtc/v3"
-	"github.com/pion/webrtc/v3/pkg/media/oggwriter"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
+// TranscriberOption configures optional Transcriber behavior that most
+// callers don't need, such as voice-activity segmentation.
+type TranscriberOption func(*Transcriber)
+
+// WithVAD attaches a VoiceActivityDetector to the Transcriber. When set,
+// the Transcriber emits VADEvents, stamps SegmentID/StartedAt/EndedAt on
+// RecognizeResults, and closes/reopens its speech stream on natural
+// pauses instead of only on ErrStreamExhausted.
+func WithVAD(vad VoiceActivityDetector) TranscriberOption {
+	return func(t *Transcriber) {
+		t.vad = vad
+	}
+}
+
+// WithBargeIn attaches a BargeInSignaler. When the VAD reports
+// SpeechStarted while the signaler's assistant-speaking flag is set, the
+// Transcriber publishes a barge-in notification to it. Requires
+// WithVAD.
+func WithBargeIn(signaler *BargeInSignaler) TranscriberOption {
+	return func(t *Transcriber) {
+		t.bargeIn = signaler
+	}
+}
+
 type Transcriber struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	speechClient *stt.Client
-	language     *Language
+	recognizer SpeechRecognizer
+	language   *Language
 
 	rtpCodec webrtc.RTPCodecParameters
 	sb       *samplebuilder.SampleBuilder
 
-	lock          sync.Mutex
-	oggWriter     *io.PipeWriter
-	oggReader     *io.PipeReader
-	oggSerializer *oggwriter.OggWriter
+	lock   sync.Mutex
+	frames chan []byte
+
+	vad       VoiceActivityDetector
+	bargeIn   *BargeInSignaler
+	vadEvents chan VADEvent
+
+	// segLock guards speaking/segmentID/segStart. It's shared by VAD
+	// segmentation and recording's duration-based rollover so the two
+	// never disagree about which segment is current.
+	segLock   sync.Mutex
+	speaking  bool
+	segmentID int
+	segStart  time.Time
+
+	recording *recording
+	pcmOutput *TranscriberPCMSource
 
 	results chan RecognizeResult
 	closeCh chan struct{}
 }
 
-type RecognizeResult struct {
-	Error   error
-	Text    string
-	IsFinal bool
-}
-
-func NewTranscriber(rtpCodec webrtc.RTPCodecParameters, speechClient *stt.Client, language *Language) (*Transcriber, error) {
+func NewTranscriber(rtpCodec webrtc.RTPCodecParameters, recognizer SpeechRecognizer, language *Language, opts ...TranscriberOption) (*Transcriber, error) {
 	if !strings.EqualFold(rtpCodec.MimeType, "audio/opus") {
 		return nil, errors.New("only opus is supported")
 	}
 
-	oggReader, oggWriter := io.Pipe()
 	ctx, cancel := context.WithCancel(context.Background())
 	t := &Transcriber{
-		ctx:          ctx,
-		cancel:       cancel,
-		rtpCodec:     rtpCodec,
-		sb:           samplebuilder.New(200, &codecs.OpusPacket{}, rtpCodec.ClockRate),
-		oggReader:    oggReader,
-		oggWriter:    oggWriter,
-		language:     language,
-		speechClient: speechClient,
-		results:      make(chan RecognizeResult),
-		closeCh:      make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+		rtpCodec:   rtpCodec,
+		sb:         samplebuilder.New(200, &codecs.OpusPacket{}, rtpCodec.ClockRate),
+		language:   language,
+		recognizer: recognizer,
+		frames:     make(chan []byte),
+		vadEvents:  make(chan VADEvent, 16),
+		results:    make(chan RecognizeResult),
+		closeCh:    make(chan struct{}),
 	}
+	t.segStart = time.Now()
+	for _, opt := range opts {
+		opt(t)
+	}
+
 	go t.start()
 	return t, nil
 }
@@ -71,19 +101,28 @@ func (t *Transcriber) WriteRTP(pkt *rtp.Packet) error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	if t.oggSerializer == nil {
-		oggSerializer, err := oggwriter.NewWith(t.oggWriter, t.rtpCodec.ClockRate, t.rtpCodec.Channels)
+	t.sb.Push(pkt)
+	for _, p := range t.sb.PopPackets() {
+		if t.recording != nil {
+			if err := t.recording.write(p, t.rtpCodec.ClockRate, uint16(t.rtpCodec.Channels)); err != nil {
+				logger.Errorw("failed to write recording segment", err)
+			}
+		}
+		if t.pcmOutput != nil {
+			if err := t.pcmOutput.writeRTP(p); err != nil {
+				logger.Errorw("failed to decode opus frame for PCM output", err)
+			}
+		}
+
+		raw, err := p.Marshal()
 		if err != nil {
-			logger.Errorw("failed to create ogg serializer", err)
 			return err
 		}
-		t.oggSerializer = oggSerializer
-	}
 
-	t.sb.Push(pkt)
-	for _, p := range t.sb.PopPackets() {
-		if err := t.oggSerializer.WriteRTP(p); err != nil {
-			return err
+		select {
+		case t.frames <- raw:
+		case <-t.ctx.Done():
+			return t.ctx.Err()
 		}
 	}
 
@@ -91,192 +130,222 @@ func (t *Transcriber) WriteRTP(pkt *rtp.Packet) error {
 }
 
 func (t *Transcriber) start() error {
-	defer func() {
-		close(t.closeCh)
-	}()
+	defer close(t.closeCh)
+
+	params := RecognitionParams{
+		SampleRate: int(t.rtpCodec.ClockRate),
+		Channels:   int(t.rtpCodec.Channels),
+		Language:   t.language,
+	}
 
 	for {
 		logger.Debugw("creating a new speech stream")
 
-		stream, err := t.newStream()
+		stream, err := t.recognizer.StartStream(t.ctx, params)
 		if err != nil {
 			return err
 		}
 		endStreamCh := make(chan struct{})
 		nextCh := make(chan struct{})
+		pauseCh := make(chan struct{}, 1)
 
-		// Forward track packets to the speech stream
+		// Forward track frames to the speech stream
 		go func() {
 			defer close(nextCh)
-			buf := make([]byte, 1024)
 			for {
 				select {
 				case <-endStreamCh:
 					return
-				default:
-					n, err := t.oggReader.Read(buf)
-					if err != nil {
-						if err != io.EOF {
-							logger.Errorw("failed to read from ogg reader", err)
-						}
-						return
+				case frame := <-t.frames:
+					if t.vad != nil {
+						t.processVAD(frame, pauseCh)
 					}
-
-					if n <= 0 {
-						// No data
-						continue
-					}
-
-					logger.Debugw("sending audio content to speech stream", "n", n)
-					// Forward to speech stream
-					if err := stream.Send(&sttpb.StreamingRecognizeRequest{
-						StreamingRequest: &sttpb.StreamingRecognizeRequest_AudioContent{
-							AudioContent: buf[:n],
-						},
-					}); err != nil {
-						if err != io.EOF {
-							logger.Errorw("failed to send audio content to speech stream", err)
-							t.results <- RecognizeResult{
-								Error: err,
-							}
-						}
+					if err := stream.Write(frame); err != nil {
+						logger.Errorw("failed to write audio frame to speech stream", err)
+						t.results <- RecognizeResult{Error: err}
 						return
 					}
 				}
 			}
-
 		}()
 
-		// Read transcription results
-		for {
-			resp, err := stream.Recv()
-			if err != nil {
-				if status, ok := status.FromError(err); ok {
-					if status.Code() == codes.OutOfRange {
-						// Create a new speech stream (maximum speech length exceeded)
-						break
-					} else if status.Code() == codes.Canceled {
-						// Context canceled (Stop)
-						return nil
-					}
-				}
+		recreate := t.recvUntilPause(stream, pauseCh)
 
-				logger.Errorw("failed to receive response from speech stream", err)
-				t.results <- RecognizeResult{
-					Error: err,
-				}
+		close(endStreamCh)
+		<-nextCh
+		stream.Close()
 
-				return err
-			}
+		if !recreate {
+			return nil
+		}
+		// Natural pause or recognizer limit hit, loop around and open a
+		// fresh stream.
+	}
+}
 
-			if resp.Error != nil {
-				continue
+// recvMsg carries a single stream.Recv() result across recvUntilPause's
+// internal goroutine boundary.
+type recvMsg struct {
+	result RecognizeResult
+	err    error
+}
+
+// recvUntilPause reads results from stream until it needs to be
+// recreated (pause or ErrStreamExhausted), returning true in that case,
+// or false once the Transcriber has been stopped or hit a fatal error.
+func (t *Transcriber) recvUntilPause(stream RecognizerStream, pauseCh <-chan struct{}) bool {
+	recvCh := make(chan recvMsg)
+	recvDone := make(chan struct{})
+	go func() {
+		defer close(recvCh)
+		for {
+			result, err := stream.Recv()
+			select {
+			case recvCh <- recvMsg{result, err}:
+			case <-recvDone:
+				return
 			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	defer close(recvDone)
+
+	for {
+		select {
+		case <-pauseCh:
+			return true
 
-			var sb strings.Builder
-			final := false
-			for _, result := range resp.Results {
-				alt := result.Alternatives[0]
-				text := alt.Transcript
-				sb.WriteString(text)
-
-				if result.IsFinal {
-					sb.Reset()
-					sb.WriteString(text)
-					final = true
-					break
+		case msg, ok := <-recvCh:
+			if !ok {
+				return false
+			}
+			if msg.err != nil {
+				if errors.Is(msg.err, ErrStreamExhausted) {
+					// Maximum speech length exceeded.
+					return true
+				} else if errors.Is(msg.err, context.Canceled) {
+					// Context canceled (Stop)
+					return false
 				}
+
+				logger.Errorw("failed to receive response from speech stream", msg.err)
+				t.results <- RecognizeResult{Error: msg.err}
+				return false
 			}
 
-			t.results <- RecognizeResult{
-				Text:    sb.String(),
-				IsFinal: final,
+			result := t.annotateSegment(msg.result)
+			if t.recording != nil && result.IsFinal {
+				t.recording.recordTranscript(result)
 			}
+			t.results <- result
 		}
+	}
+}
 
-		close(endStreamCh)
-		<-nextCh
+// annotateSegment stamps the current segment onto result when a
+// VoiceActivityDetector or recording is configured.
+func (t *Transcriber) annotateSegment(result RecognizeResult) RecognizeResult {
+	if t.vad == nil && t.recording == nil {
+		return result
+	}
 
-		// Create a new oggSerializer each time we open a new SpeechStream
-		// This is required because the stream requires ogg headers to be sent again
-		t.lock.Lock()
-		t.oggSerializer = nil
-		t.lock.Unlock()
+	id, started := t.currentSegment()
+	result.SegmentID = id
+	result.StartedAt = started
+	if result.IsFinal {
+		result.EndedAt = time.Now()
 	}
+
+	return result
 }
 
-func (t *Transcriber) Close() {
-	t.cancel()
-	<-t.closeCh
-	t.oggWriter.Close()
-	close(t.results)
+// currentSegment returns the active segment id and when it started.
+func (t *Transcriber) currentSegment() (int, time.Time) {
+	t.segLock.Lock()
+	defer t.segLock.Unlock()
+	return t.segmentID, t.segStart
 }
 
-func (t *Transcriber) Results() <-chan RecognizeResult {
-	return t.results
+// startNewSegment bumps the shared segment counter -- used by VAD on
+// SpeechStarted, and by recording's duration-based rollover -- and
+// returns the new id.
+func (t *Transcriber) startNewSegment() (int, time.Time) {
+	t.segLock.Lock()
+	defer t.segLock.Unlock()
+	t.segmentID++
+	t.segStart = time.Now()
+	return t.segmentID, t.segStart
 }
 
-func (t *Transcriber) newStream() (sttpb.Speech_StreamingRecognizeClient, error) {
-	stream, err := t.speechClient.StreamingRecognize(t.ctx)
-	if err != nil {
-		return nil, err
+// processVAD feeds frame's Opus payload to the configured VAD and
+// publishes SpeechStarted/SpeechEnded transitions. On SpeechEnded it
+// also signals pauseCh so the caller can close the current stream on a
+// natural pause rather than waiting for ErrStreamExhausted.
+func (t *Transcriber) processVAD(frame []byte, pauseCh chan<- struct{}) {
+	pkt := &rtp.Packet{}
+	if err := pkt.Unmarshal(frame); err != nil {
+		return
+	}
+	active := t.vad.Detect(pkt.Payload)
+
+	t.segLock.Lock()
+	wasSpeaking := t.speaking
+	t.speaking = active
+	t.segLock.Unlock()
+
+	var segmentID int
+	if active && !wasSpeaking {
+		segmentID, _ = t.startNewSegment()
+	} else {
+		segmentID, _ = t.currentSegment()
 	}
 
-	config := &sttpb.RecognitionConfig{
-		Model: "command_and_search",
-		Adaptation: &sttpb.SpeechAdaptation{
-			PhraseSets: []*sttpb.PhraseSet{
-				{
-					Phrases: []*sttpb.PhraseSet_Phrase{
-						{Value: "${hello} ${gpt}"},
-						{Value: "${gpt}"},
-						{Value: "Hey ${gpt}"},
-					},
-					Boost: 19,
-				},
-			},
-			CustomClasses: []*sttpb.CustomClass{
-				{
-					CustomClassId: "hello",
-					Items: []*sttpb.CustomClass_ClassItem{
-						{Value: "Hi"},
-						{Value: "Hello"},
-						{Value: "Hey"},
-					},
-				},
-				{
-					CustomClassId: "gpt",
-					Items: []*sttpb.CustomClass_ClassItem{
-						{Value: "Kit"},
-						{Value: "KITT"},
-						{Value: "GPT"},
-						{Value: "Live Kit"},
-						{Value: "Live GPT"},
-						{Value: "LiveKit"},
-						{Value: "LiveGPT"},
-						{Value: "Live-Kit"},
-						{Value: "Live-GPT"},
-					},
-				},
-			},
-		},
-		UseEnhanced:       true,
-		Encoding:          sttpb.RecognitionConfig_OGG_OPUS,
-		SampleRateHertz:   int32(t.rtpCodec.ClockRate),
-		AudioChannelCount: int32(t.rtpCodec.Channels),
-		LanguageCode:      t.language.Code,
+	if active == wasSpeaking {
+		return
 	}
 
-	if err := stream.Send(&sttpb.StreamingRecognizeRequest{
-		StreamingRequest: &sttpb.StreamingRecognizeRequest_StreamingConfig{
-			StreamingConfig: &sttpb.StreamingRecognitionConfig{
-				InterimResults: true,
-				Config:         config,
-			},
-		},
-	}); err != nil {
-		return nil, err
+	if active {
+		if t.bargeIn != nil && t.bargeIn.isAssistantSpeaking() {
+			t.bargeIn.Publish()
+		}
+		t.publishVADEvent(VADEvent{Type: VADEventSpeechStarted, SegmentID: segmentID, At: time.Now()})
+		return
 	}
 
-	return stream, nil
+	t.publishVADEvent(VADEvent{Type: VADEventSpeechEnded, SegmentID: segmentID, At: time.Now()})
+	select {
+	case pauseCh <- struct{}{}:
+	default:
+	}
+}
+
+func (t *Transcriber) publishVADEvent(event VADEvent) {
+	select {
+	case t.vadEvents <- event:
+	default:
+		logger.Debugw("dropping VAD event, channel full", "type", event.Type)
+	}
+}
+
+// VADEvents returns the channel SpeechStarted/SpeechEnded events are
+// published on. It's closed by Close, and never receives events when no
+// VAD is configured.
+func (t *Transcriber) VADEvents() <-chan VADEvent {
+	return t.vadEvents
+}
+
+func (t *Transcriber) Close() {
+	t.cancel()
+	<-t.closeCh
+	if t.recording != nil {
+		t.recording.close()
+	}
+	close(t.results)
+	close(t.vadEvents)
+}
+
+func (t *Transcriber) Results() <-chan RecognizeResult {
+	return t.results
 }
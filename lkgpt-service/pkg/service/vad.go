@@ -0,0 +1,79 @@
+package service
+
+import "time"
+
+// VADEventType distinguishes the two events a VoiceActivityDetector can
+// produce for a Transcriber.
+type VADEventType int
+
+const (
+	// VADEventSpeechStarted fires the first time a frame is classified
+	// as speech after a period of silence.
+	VADEventSpeechStarted VADEventType = iota
+	// VADEventSpeechEnded fires once enough consecutive silent frames
+	// have been seen to consider the utterance over.
+	VADEventSpeechEnded
+)
+
+// VADEvent is published on a Transcriber's VAD channel whenever voice
+// activity starts or stops.
+type VADEvent struct {
+	Type      VADEventType
+	SegmentID int
+	At        time.Time
+}
+
+// VoiceActivityDetector decides, frame by frame, whether a participant
+// is currently speaking. Implementations may work on decoded PCM (e.g.
+// WebRTC VAD) or directly on encoded Opus frames.
+type VoiceActivityDetector interface {
+	// Detect reports whether the Opus payload in frame contains speech.
+	Detect(frame []byte) bool
+}
+
+// EnergyVAD is a lightweight VoiceActivityDetector that works directly
+// on encoded Opus frames without decoding them: Opus DTX/CNG frames
+// encode silence in a handful of bytes, so a short payload is already a
+// strong signal that the frame carries no speech. HangoverFrames
+// smooths over brief pauses between words so SpeechEnded only fires on
+// a real gap.
+type EnergyVAD struct {
+	// MinSpeechBytes is the payload size at or above which a frame is
+	// classified as speech. Defaults to 8.
+	MinSpeechBytes int
+	// HangoverFrames is the number of consecutive silent frames
+	// required before speech is considered to have ended. Defaults to
+	// 20 (roughly 400ms at 20ms/frame).
+	HangoverFrames int
+
+	silentRun int
+}
+
+// NewEnergyVAD returns an EnergyVAD with its default thresholds.
+func NewEnergyVAD() *EnergyVAD {
+	return &EnergyVAD{MinSpeechBytes: 8, HangoverFrames: 20}
+}
+
+func (v *EnergyVAD) Detect(frame []byte) bool {
+	if len(frame) >= v.minSpeechBytes() {
+		v.silentRun = 0
+		return true
+	}
+
+	v.silentRun++
+	return v.silentRun < v.hangoverFrames()
+}
+
+func (v *EnergyVAD) minSpeechBytes() int {
+	if v.MinSpeechBytes > 0 {
+		return v.MinSpeechBytes
+	}
+	return 8
+}
+
+func (v *EnergyVAD) hangoverFrames() int {
+	if v.HangoverFrames > 0 {
+		return v.HangoverFrames
+	}
+	return 20
+}
@@ -0,0 +1,38 @@
+package service
+
+import "testing"
+
+func TestEnergyVADDetect(t *testing.T) {
+	v := &EnergyVAD{MinSpeechBytes: 8, HangoverFrames: 3}
+
+	if !v.Detect(make([]byte, 8)) {
+		t.Fatal("frame at MinSpeechBytes should be classified as speech")
+	}
+
+	if !v.Detect(make([]byte, 2)) {
+		t.Fatal("silent frame within hangover should still report speech")
+	}
+	if !v.Detect(make([]byte, 2)) {
+		t.Fatal("second silent frame within hangover should still report speech")
+	}
+	if v.Detect(make([]byte, 2)) {
+		t.Fatal("third consecutive silent frame should end the hangover")
+	}
+
+	if !v.Detect(make([]byte, 10)) {
+		t.Fatal("a loud frame after silence should reset to speech")
+	}
+	if v.silentRun != 0 {
+		t.Fatalf("silentRun = %d, want 0 after a speech frame", v.silentRun)
+	}
+}
+
+func TestEnergyVADDefaults(t *testing.T) {
+	v := NewEnergyVAD()
+	if v.minSpeechBytes() != 8 {
+		t.Fatalf("minSpeechBytes() = %d, want 8", v.minSpeechBytes())
+	}
+	if v.hangoverFrames() != 20 {
+		t.Fatalf("hangoverFrames() = %d, want 20", v.hangoverFrames())
+	}
+}